@@ -0,0 +1,102 @@
+package builder
+
+import "time"
+
+// Request represents the HTTP request half of an APITest: the headers, query
+// params, and body to send.
+type Request struct {
+	Headers     map[string]string      `json:"headers"`
+	QueryParams map[string]string      `json:"query_params"`
+	Body        string                 `json:"body"`
+	JSON        map[string]interface{} `json:"json"`
+
+	// Files maps a multipart form field name to the path of a file on disk.
+	// When non-empty, buildRequest sends a multipart/form-data body with one
+	// file part per entry, plus one text field per JSON entry; Body is not sent.
+	Files map[string]string `json:"files"`
+}
+
+// APIResponse represents the expected HTTP response half of an APITest.
+type APIResponse struct {
+	StatusCode int                    `json:"status_code"`
+	Body       string                 `json:"body"`
+	JSON       map[string]interface{} `json:"json"`
+	Headers    map[string]string      `json:"headers"`
+
+	// JSONPath maps a JSONPath expression to an expected value. The expected
+	// value may be a literal to compare for equality, or a string encoding an
+	// operator, e.g. ">10", "!=5", "contains foo", "matches /regex/", "len==3".
+	JSONPath map[string]interface{} `json:"jsonpath"`
+
+	// XPath maps an XPath expression to an expected value, using the same
+	// operator encoding as JSONPath. It is evaluated against XML response bodies.
+	XPath map[string]string `json:"xpath"`
+
+	// Match, if set, is handed to the BodyMatcher registered for the response's
+	// Content-Type header, alongside the raw body. This lets suites assert on
+	// XML, YAML, protobuf, and multipart bodies that JSON/JSONPath can't express.
+	Match interface{} `json:"match"`
+}
+
+// APITest represents a single HTTP request/response pair to test, along with
+// the expectations the response must satisfy.
+type APITest struct {
+	Name     string      `json:"name"`
+	Method   string      `json:"method"`
+	Hostname string      `json:"hostname"`
+	Endpoint string      `json:"endpoint"`
+	Request  Request     `json:"request"`
+	Response APIResponse `json:"response"`
+
+	// Variables are static ${var} substitutions available to this test, merged
+	// with (and overridden by) any values extracted from earlier tests in a suite.
+	Variables map[string]string `json:"variables"`
+
+	// Extract maps a variable name to a JSONPath expression (or "header:Name" to
+	// pull a response header) evaluated against this test's response. Extracted
+	// values become available to later tests in the same suite.
+	Extract map[string]string `json:"extract"`
+
+	// DependsOn lists the Name of tests that must succeed before this one runs.
+	// RunSuite skips a test if any of its dependencies did not succeed.
+	DependsOn []string `json:"depends_on"`
+
+	// Retry configures how a failed attempt at this test is retried.
+	Retry Retry `json:"retry"`
+
+	// Timeout bounds how long a single attempt may take. Zero means no timeout.
+	Timeout time.Duration `json:"timeout"`
+
+	// MaxLatency, if non-zero, fails the test when the response takes longer
+	// than this to arrive, even if the status code and body are otherwise correct.
+	MaxLatency time.Duration `json:"max_latency"`
+}
+
+// BackoffStrategy selects how long to wait between retry attempts.
+type BackoffStrategy string
+
+const (
+	BackoffFixed       BackoffStrategy = "fixed"
+	BackoffExponential BackoffStrategy = "exponential"
+	BackoffJitter      BackoffStrategy = "jitter"
+)
+
+// Retry configures retry behaviour for a single APITest.
+type Retry struct {
+	// Count is the number of retries attempted after an initial failure. Zero
+	// means the test is only attempted once.
+	Count int `json:"count"`
+
+	// Backoff selects the wait strategy between attempts. Defaults to BackoffFixed.
+	Backoff BackoffStrategy `json:"backoff"`
+
+	// Interval is the base wait applied by Backoff. Defaults to 100ms.
+	Interval time.Duration `json:"interval"`
+
+	// OnStatusCodes lists response status codes that should trigger a retry,
+	// e.g. a flaky staging environment's transient 502s.
+	OnStatusCodes []int `json:"on_status_codes"`
+
+	// OnNetworkError retries when the request fails before a response is received.
+	OnNetworkError bool `json:"on_network_error"`
+}
@@ -0,0 +1,183 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+
+	"github.com/clbanning/mxj"
+	"github.com/golang/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// BodyMatcher compares a response body against an expected value for a given
+// content type. Matchers are registered by MIME type via RegisterBodyMatcher
+// and consulted by assertResponse when an APIResponse.Match is set.
+type BodyMatcher interface {
+	Match(contentType string, body []byte, expected interface{}) (bool, error)
+}
+
+var bodyMatchers = map[string]BodyMatcher{
+	"application/xml":        xmlBodyMatcher{},
+	"application/yaml":       yamlBodyMatcher{},
+	"application/x-protobuf": protobufBodyMatcher{},
+	"multipart/form-data":    multipartBodyMatcher{},
+}
+
+// RegisterBodyMatcher makes matcher available for the given MIME type,
+// overwriting whatever was previously registered for it. This lets callers
+// add support for content types beyond the built-ins, or override one of them.
+func RegisterBodyMatcher(contentType string, matcher BodyMatcher) {
+	bodyMatchers[contentType] = matcher
+}
+
+// lookupBodyMatcher resolves the matcher registered for contentType, ignoring
+// any "; charset=..." style parameters.
+func lookupBodyMatcher(contentType string) (BodyMatcher, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+
+	matcher, ok := bodyMatchers[mediaType]
+	return matcher, ok
+}
+
+// xmlBodyMatcher normalizes an XML body to a map[string]interface{} and
+// reuses assertJSON's subset-match semantics against the expected map.
+type xmlBodyMatcher struct{}
+
+func (xmlBodyMatcher) Match(contentType string, body []byte, expected interface{}) (bool, error) {
+	expectedMap, ok := expected.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("expected a map[string]interface{} for XML body matching, got %T", expected)
+	}
+
+	actual, err := mxj.NewMapXml(body)
+	if err != nil {
+		return false, fmt.Errorf("response body did not contain valid XML: %v", err)
+	}
+
+	return assertJSON(map[string]interface{}(actual), expectedMap), nil
+}
+
+// yamlBodyMatcher decodes a YAML body to a map[string]interface{} and reuses
+// assertJSON's subset-match semantics against the expected map.
+type yamlBodyMatcher struct{}
+
+func (yamlBodyMatcher) Match(contentType string, body []byte, expected interface{}) (bool, error) {
+	expectedMap, ok := expected.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("expected a map[string]interface{} for YAML body matching, got %T", expected)
+	}
+
+	actual := make(map[string]interface{})
+	if err := yaml.Unmarshal(body, &actual); err != nil {
+		return false, fmt.Errorf("response body did not contain valid YAML: %v", err)
+	}
+
+	return assertJSON(normalizeYAML(actual), expectedMap), nil
+}
+
+// normalizeYAML recursively converts the map[interface{}]interface{} nodes
+// that yaml.v2 produces for nested objects into map[string]interface{}, which
+// is what assertJSON/removeExtraKeys expect when pruning and comparing.
+func normalizeYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for key, val := range t {
+			out[fmt.Sprintf("%v", key)] = normalizeYAML(val)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for key, val := range t {
+			out[key] = normalizeYAML(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = normalizeYAML(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// ProtoExpectation pairs a factory for the response's proto.Message type with
+// the expected message, since BodyMatcher.Match only sees an opaque
+// expected interface{} and proto.Unmarshal needs a concrete instance to fill in.
+type ProtoExpectation struct {
+	Factory func() proto.Message
+	Want    proto.Message
+}
+
+// protobufBodyMatcher unmarshals a protobuf body using the caller-supplied
+// factory and compares it against the expected message with proto.Equal.
+type protobufBodyMatcher struct{}
+
+func (protobufBodyMatcher) Match(contentType string, body []byte, expected interface{}) (bool, error) {
+	exp, ok := expected.(ProtoExpectation)
+	if !ok {
+		return false, fmt.Errorf("expected a ProtoExpectation for protobuf body matching, got %T", expected)
+	}
+
+	actual := exp.Factory()
+	if err := proto.Unmarshal(body, actual); err != nil {
+		return false, fmt.Errorf("response body did not contain a valid %T: %v", actual, err)
+	}
+
+	return proto.Equal(actual, exp.Want), nil
+}
+
+// multipartBodyMatcher reads every part of a multipart/form-data body into a
+// field name => value map and compares it against the expected fields.
+type multipartBodyMatcher struct{}
+
+func (multipartBodyMatcher) Match(contentType string, body []byte, expected interface{}) (bool, error) {
+	// A fixture file decodes its "match" object as map[string]interface{}
+	// (encoding/json never produces map[string]string), so accept that shape
+	// and stringify each value rather than requiring a concrete string map.
+	expectedFields, ok := expected.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("expected a map[string]interface{} for multipart body matching, got %T", expected)
+	}
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false, fmt.Errorf("invalid multipart content type %q: %v", contentType, err)
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	actual := make(map[string]string)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			return false, err
+		}
+
+		actual[part.FormName()] = string(data)
+	}
+
+	for field, want := range expectedFields {
+		if actual[field] != fmt.Sprintf("%v", want) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"bytes"
+	"mime/multipart"
+	"testing"
+)
+
+func TestMultipartBodyMatcher(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+	if err := writer.WriteField("name", "alice"); err != nil {
+		t.Fatalf("unexpected error writing field: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	matcher := multipartBodyMatcher{}
+
+	// The registry only ever hands matchers an expected value decoded from
+	// JSON/YAML, which means map[string]interface{}, never map[string]string.
+	ok, err := matcher.Match(writer.FormDataContentType(), buffer.Bytes(), map[string]interface{}{"name": "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Error("expected matching fields to match")
+	}
+
+	ok, err = matcher.Match(writer.FormDataContentType(), buffer.Bytes(), map[string]interface{}{"name": "bob"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Error("expected a mismatched field value to fail")
+	}
+}
+
+func TestYAMLBodyMatcherNestedFields(t *testing.T) {
+	body := []byte("user:\n  name: alice\n  roles:\n    - admin\n")
+
+	matcher := yamlBodyMatcher{}
+
+	ok, err := matcher.Match("application/yaml", body, map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "alice",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Error("expected a nested field subset match to succeed")
+	}
+}
+
+func TestNormalizeYAML(t *testing.T) {
+	input := map[interface{}]interface{}{
+		"a": map[interface{}]interface{}{
+			"b": "c",
+		},
+		"list": []interface{}{
+			map[interface{}]interface{}{"d": "e"},
+		},
+	}
+
+	normalized := normalizeYAML(input)
+
+	outer, ok := normalized.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map[string]interface{}, got %T", normalized)
+	}
+
+	inner, ok := outer["a"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a normalized nested map, got %T", outer["a"])
+	}
+	if inner["b"] != "c" {
+		t.Errorf("inner[b] = %v, want c", inner["b"])
+	}
+
+	list, ok := outer["list"].([]interface{})
+	if !ok || len(list) != 1 {
+		t.Fatalf("expected a one-element slice, got %v", outer["list"])
+	}
+	if _, ok := list[0].(map[string]interface{}); !ok {
+		t.Errorf("expected the list element to be normalized, got %T", list[0])
+	}
+}
+
+func TestLookupBodyMatcher(t *testing.T) {
+	if _, ok := lookupBodyMatcher("application/xml; charset=utf-8"); !ok {
+		t.Error("expected a matcher for application/xml even with charset params")
+	}
+	if _, ok := lookupBodyMatcher("text/plain"); ok {
+		t.Error("expected no matcher registered for text/plain")
+	}
+}
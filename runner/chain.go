@@ -0,0 +1,183 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/PaesslerAG/jsonpath"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// RunSuite executes tests in the order their DependsOn fields allow, threading
+// a shared variable context between them: each test's Extract expressions are
+// evaluated against its response and merged into the context available to
+// later tests via ${var} interpolation. A test whose dependencies did not all
+// succeed is skipped rather than run.
+func RunSuite(tests []builder.APITest, client *http.Client) ([]RunReport, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	order, err := dependencyOrder(tests)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]RunReport, 0, len(tests))
+	succeeded := make(map[string]bool, len(tests))
+	vars := make(map[string]string)
+
+	for _, test := range order {
+		if !dependenciesSucceeded(test, succeeded) {
+			reports = append(reports, RunReport{
+				Test:           test,
+				Successful:     false,
+				FailureMessage: fmt.Sprintf("skipped: a dependency of %q did not succeed", test.Name),
+			})
+			continue
+		}
+
+		report, body, header := runWithVars(test, client, mergeVars(test.Variables, vars))
+		reports = append(reports, report)
+
+		if test.Name != "" {
+			succeeded[test.Name] = report.Successful
+		}
+
+		if report.Successful && len(test.Extract) > 0 {
+			extracted, err := extractVariables(body, header, test.Extract)
+			if err != nil {
+				continue
+			}
+			for name, value := range extracted {
+				vars[name] = value
+			}
+		}
+	}
+
+	return reports, nil
+}
+
+// dependenciesSucceeded reports whether every name in test.DependsOn is present
+// in succeeded and true.
+func dependenciesSucceeded(test builder.APITest, succeeded map[string]bool) bool {
+	for _, dep := range test.DependsOn {
+		if !succeeded[dep] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dependencyOrder topologically sorts tests by DependsOn, preserving the
+// original relative order among tests that become ready at the same time.
+func dependencyOrder(tests []builder.APITest) ([]builder.APITest, error) {
+	names := make(map[string]bool, len(tests))
+	for _, test := range tests {
+		if test.Name != "" {
+			names[test.Name] = true
+		}
+	}
+
+	placed := make(map[string]bool, len(tests))
+	order := make([]builder.APITest, 0, len(tests))
+	remaining := tests
+
+	for len(remaining) > 0 {
+		var next []builder.APITest
+		progressed := false
+
+		for _, test := range remaining {
+			ready := true
+			for _, dep := range test.DependsOn {
+				if !names[dep] {
+					return nil, fmt.Errorf("test %q depends on unknown test %q", test.Name, dep)
+				}
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+
+			if !ready {
+				next = append(next, test)
+				continue
+			}
+
+			order = append(order, test)
+			if test.Name != "" {
+				placed[test.Name] = true
+			}
+			progressed = true
+		}
+
+		if !progressed {
+			return nil, fmt.Errorf("cyclic DependsOn among tests: %v", dependentNames(next))
+		}
+
+		remaining = next
+	}
+
+	return order, nil
+}
+
+func dependentNames(tests []builder.APITest) []string {
+	out := make([]string, len(tests))
+	for i, test := range tests {
+		out[i] = test.Name
+	}
+	return out
+}
+
+// mergeVars combines base and overrides into a new map, with overrides taking
+// precedence on key collisions.
+func mergeVars(base, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// extractVariables evaluates each Extract expression against a test's response.
+// An expression of the form "header:Name" pulls a response header; anything
+// else is evaluated as a JSONPath expression against the JSON response body.
+func extractVariables(body []byte, header http.Header, extract map[string]string) (map[string]string, error) {
+	var data interface{}
+	hasJSON := json.Unmarshal(body, &data) == nil
+
+	vars := make(map[string]string, len(extract))
+	for name, expr := range extract {
+		if rest, ok := cutPrefix(expr, "header:"); ok {
+			vars[name] = header.Get(rest)
+			continue
+		}
+
+		if !hasJSON {
+			return nil, fmt.Errorf("cannot extract %v: response body is not valid JSON", name)
+		}
+
+		val, err := jsonpath.Get(expr, data)
+		if err != nil {
+			return nil, fmt.Errorf("cannot extract %v: %v", name, err)
+		}
+
+		vars[name] = fmt.Sprintf("%v", val)
+	}
+
+	return vars, nil
+}
+
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return strings.TrimPrefix(s, prefix), true
+}
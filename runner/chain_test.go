@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+func TestDependencyOrder(t *testing.T) {
+	tests := []builder.APITest{
+		{Name: "c", DependsOn: []string{"b"}},
+		{Name: "a"},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+
+	order, err := dependencyOrder(tests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make([]string, len(order))
+	for i, test := range order {
+		got[i] = test.Name
+	}
+
+	want := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencyOrder = %v, want %v", got, want)
+	}
+}
+
+func TestDependencyOrderUnknownDependency(t *testing.T) {
+	tests := []builder.APITest{
+		{Name: "a", DependsOn: []string{"missing"}},
+	}
+
+	if _, err := dependencyOrder(tests); err == nil {
+		t.Fatal("expected an error for an unknown dependency, got none")
+	}
+}
+
+func TestDependencyOrderCycle(t *testing.T) {
+	tests := []builder.APITest{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+
+	if _, err := dependencyOrder(tests); err == nil {
+		t.Fatal("expected an error for a dependency cycle, got none")
+	}
+}
+
+func TestMergeVars(t *testing.T) {
+	base := map[string]string{"a": "1", "b": "2"}
+	overrides := map[string]string{"b": "3", "c": "4"}
+
+	got := mergeVars(base, overrides)
+	want := map[string]string{"a": "1", "b": "3", "c": "4"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeVars = %v, want %v", got, want)
+	}
+
+	if base["b"] != "2" || overrides["a"] != "" {
+		t.Errorf("mergeVars mutated an input map: base=%v overrides=%v", base, overrides)
+	}
+}
+
+func TestDependenciesSucceeded(t *testing.T) {
+	succeeded := map[string]bool{"a": true, "b": false}
+
+	if !dependenciesSucceeded(builder.APITest{DependsOn: []string{"a"}}, succeeded) {
+		t.Error("expected a dependency on a successful test to be satisfied")
+	}
+	if dependenciesSucceeded(builder.APITest{DependsOn: []string{"b"}}, succeeded) {
+		t.Error("expected a dependency on a failed test to be unsatisfied")
+	}
+	if dependenciesSucceeded(builder.APITest{DependsOn: []string{"unknown"}}, succeeded) {
+		t.Error("expected a dependency on an unknown test to be unsatisfied")
+	}
+}
+
+func TestExtractVariablesHeaderAndJSONPath(t *testing.T) {
+	header := http.Header{"X-Token": {"abc123"}}
+
+	vars, err := extractVariables([]byte(`{"id": 42}`), header, map[string]string{
+		"token": "header:X-Token",
+		"id":    "$.id",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if vars["token"] != "abc123" {
+		t.Errorf("token = %v, want abc123", vars["token"])
+	}
+	if vars["id"] != "42" {
+		t.Errorf("id = %v, want 42", vars["id"])
+	}
+}
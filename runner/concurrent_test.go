@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// TestRunTestsConcurrentPreservesOrder exercises the ordering guarantee
+// directly: tests are deliberately given decreasing delays so they complete
+// in the reverse of their input order, and the reports must still come back
+// in input order.
+func TestRunTestsConcurrentPreservesOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delay := r.URL.Query().Get("delay"); delay != "" {
+			if ms, err := strconv.Atoi(delay); err == nil {
+				time.Sleep(time.Duration(ms) * time.Millisecond)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := make([]builder.APITest, 10)
+	for i := range tests {
+		delay := (len(tests) - i) * 5
+		tests[i] = builder.APITest{
+			Name:     strconv.Itoa(i),
+			Method:   http.MethodGet,
+			Hostname: server.URL,
+			Endpoint: "/",
+			Request: builder.Request{
+				QueryParams: map[string]string{"delay": strconv.Itoa(delay)},
+			},
+			Response: builder.APIResponse{StatusCode: http.StatusOK},
+		}
+	}
+
+	reports := RunTestsConcurrent(tests, RunOptions{Parallelism: 5})
+
+	if len(reports) != len(tests) {
+		t.Fatalf("expected %d reports, got %d", len(tests), len(reports))
+	}
+
+	for i, report := range reports {
+		if report.Test.Name != strconv.Itoa(i) {
+			t.Errorf("report %d: expected test %q, got %q", i, strconv.Itoa(i), report.Test.Name)
+		}
+		if !report.Successful {
+			t.Errorf("report %d: expected success, got error %v", i, report.Error)
+		}
+	}
+}
+
+func TestHostThrottleLimitsConcurrency(t *testing.T) {
+	throttle := newHostThrottle(2)
+
+	release1 := throttle.acquire("host")
+	release2 := throttle.acquire("host")
+
+	acquired := make(chan struct{})
+	go func() {
+		release3 := throttle.acquire("host")
+		release3()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected a third acquire to block while the limit is held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release1()
+
+	select {
+	case <-acquired:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("expected the third acquire to proceed once a slot was released")
+	}
+
+	release2()
+}
+
+func TestHostThrottleUnlimited(t *testing.T) {
+	throttle := newHostThrottle(0)
+
+	release := throttle.acquire("host")
+	release()
+}
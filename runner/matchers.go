@@ -0,0 +1,221 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/antchfx/xmlquery"
+)
+
+// operator identifies the comparison to apply between an actual value extracted
+// from a response body and the operand encoded in an expectation.
+type operator string
+
+const (
+	opEq       operator = "=="
+	opNeq      operator = "!="
+	opGt       operator = ">"
+	opLt       operator = "<"
+	opContains operator = "contains"
+	opMatches  operator = "matches"
+	opLen      operator = "len=="
+)
+
+// expectation is an operator paired with the operand to compare against.
+type expectation struct {
+	op      operator
+	operand string
+}
+
+// parseExpectation splits a raw expected value into an operator and operand.
+// A value with no recognised operator prefix is treated as a plain equality
+// check against its string representation.
+func parseExpectation(expected interface{}) expectation {
+	s, ok := expected.(string)
+	if !ok {
+		return expectation{op: opEq, operand: fmt.Sprintf("%v", expected)}
+	}
+
+	switch {
+	case strings.HasPrefix(s, "len=="):
+		return expectation{op: opLen, operand: strings.TrimSpace(strings.TrimPrefix(s, "len=="))}
+	case strings.HasPrefix(s, "matches "):
+		pattern := strings.TrimSpace(strings.TrimPrefix(s, "matches "))
+		pattern = strings.TrimPrefix(pattern, "/")
+		pattern = strings.TrimSuffix(pattern, "/")
+		return expectation{op: opMatches, operand: pattern}
+	case strings.HasPrefix(s, "contains "):
+		return expectation{op: opContains, operand: strings.TrimSpace(strings.TrimPrefix(s, "contains "))}
+	case strings.HasPrefix(s, "!="):
+		return expectation{op: opNeq, operand: strings.TrimSpace(strings.TrimPrefix(s, "!="))}
+	case strings.HasPrefix(s, ">"):
+		return expectation{op: opGt, operand: strings.TrimSpace(strings.TrimPrefix(s, ">"))}
+	case strings.HasPrefix(s, "<"):
+		return expectation{op: opLt, operand: strings.TrimSpace(strings.TrimPrefix(s, "<"))}
+	default:
+		return expectation{op: opEq, operand: s}
+	}
+}
+
+// evaluate applies e's operator to actual, returning whether it is satisfied.
+func (e expectation) evaluate(actual interface{}) (bool, error) {
+	switch e.op {
+	case opLen:
+		n, err := length(actual)
+		if err != nil {
+			return false, err
+		}
+		want, err := strconv.Atoi(e.operand)
+		if err != nil {
+			return false, fmt.Errorf("invalid len== operand %q: %v", e.operand, err)
+		}
+		return n == want, nil
+	case opMatches:
+		re, err := regexp.Compile(e.operand)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex %q: %v", e.operand, err)
+		}
+		return re.MatchString(fmt.Sprintf("%v", actual)), nil
+	case opContains:
+		return strings.Contains(fmt.Sprintf("%v", actual), e.operand), nil
+	case opGt, opLt:
+		a, err := toFloat(actual)
+		if err != nil {
+			return false, err
+		}
+		want, err := strconv.ParseFloat(e.operand, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid numeric operand %q: %v", e.operand, err)
+		}
+		if e.op == opGt {
+			return a > want, nil
+		}
+		return a < want, nil
+	case opNeq:
+		return fmt.Sprintf("%v", actual) != e.operand, nil
+	default: // opEq
+		return fmt.Sprintf("%v", actual) == e.operand, nil
+	}
+}
+
+func length(v interface{}) (int, error) {
+	switch t := v.(type) {
+	case []interface{}:
+		return len(t), nil
+	case string:
+		return len(t), nil
+	case map[string]interface{}:
+		return len(t), nil
+	default:
+		return 0, fmt.Errorf("len== cannot be applied to %T", v)
+	}
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case string:
+		return strconv.ParseFloat(t, 64)
+	default:
+		return 0, fmt.Errorf("cannot compare non-numeric value %v (%T)", v, v)
+	}
+}
+
+// matchFailure describes a single failed JSONPath/XPath expectation.
+type matchFailure struct {
+	path     string
+	op       operator
+	expected interface{}
+	actual   interface{}
+}
+
+func (f matchFailure) Error() string {
+	return fmt.Sprintf("path %v failed %v comparison\n\nExpected:\n%v\n\nActual:\n%v\n\n", f.path, f.op, f.expected, f.actual)
+}
+
+// assertJSONPaths evaluates each JSONPath expectation against the parsed JSON
+// body, returning the first failure encountered.
+func assertJSONPaths(body []byte, expectations map[string]interface{}) error {
+	if len(expectations) == 0 {
+		return nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return fmt.Errorf("response body did not contain valid JSON for JSONPath matching: %v", err)
+	}
+
+	for path, expected := range expectations {
+		actual, err := jsonpath.Get(path, data)
+		if err != nil {
+			return fmt.Errorf("path %v: %v", path, err)
+		}
+
+		exp := parseExpectation(expected)
+		ok, err := exp.evaluate(actual)
+		if err != nil {
+			return fmt.Errorf("path %v: %v", path, err)
+		}
+		if !ok {
+			return matchFailure{path: path, op: exp.op, expected: expected, actual: actual}
+		}
+	}
+
+	return nil
+}
+
+// findOneXPath runs xmlquery.FindOne, converting the panic it raises on an
+// invalid expression (e.g. one that doesn't compile to a node-set) into an
+// error so a single bad XPath fixture can't crash the whole test run.
+func findOneXPath(doc *xmlquery.Node, path string) (node *xmlquery.Node, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			node = nil
+			err = fmt.Errorf("invalid XPath expression %q: %v", path, r)
+		}
+	}()
+
+	return xmlquery.FindOne(doc, path), nil
+}
+
+// assertXPaths evaluates each XPath expectation against the parsed XML body,
+// returning the first failure encountered.
+func assertXPaths(body []byte, expectations map[string]string) error {
+	if len(expectations) == 0 {
+		return nil
+	}
+
+	doc, err := xmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("response body did not contain valid XML for XPath matching: %v", err)
+	}
+
+	for path, expected := range expectations {
+		node, err := findOneXPath(doc, path)
+		if err != nil {
+			return fmt.Errorf("path %v: %v", path, err)
+		}
+		if node == nil {
+			return fmt.Errorf("path %v: no match found in response body", path)
+		}
+
+		actual := node.InnerText()
+
+		exp := parseExpectation(expected)
+		ok, err := exp.evaluate(actual)
+		if err != nil {
+			return fmt.Errorf("path %v: %v", path, err)
+		}
+		if !ok {
+			return matchFailure{path: path, op: exp.op, expected: expected, actual: actual}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package runner
+
+import "testing"
+
+func TestParseExpectationAndEvaluate(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected interface{}
+		actual   interface{}
+		want     bool
+		wantErr  bool
+	}{
+		{name: "equality literal", expected: float64(42), actual: float64(42), want: true},
+		{name: "equality mismatch", expected: float64(42), actual: float64(43), want: false},
+		{name: "greater than", expected: ">10", actual: float64(11), want: true},
+		{name: "greater than fails", expected: ">10", actual: float64(5), want: false},
+		{name: "less than", expected: "<10", actual: float64(5), want: true},
+		{name: "not equal", expected: "!=foo", actual: "bar", want: true},
+		{name: "not equal fails", expected: "!=foo", actual: "foo", want: false},
+		{name: "contains", expected: "contains bar", actual: "foobarbaz", want: true},
+		{name: "contains fails", expected: "contains qux", actual: "foobarbaz", want: false},
+		{name: "matches regex", expected: "matches /^[a-z]+$/", actual: "abcdef", want: true},
+		{name: "matches regex fails", expected: "matches /^[a-z]+$/", actual: "ABC123", want: false},
+		{name: "len equals", expected: "len==3", actual: []interface{}{1, 2, 3}, want: true},
+		{name: "len mismatch", expected: "len==3", actual: []interface{}{1, 2}, want: false},
+		{name: "invalid len operand", expected: "len==abc", actual: []interface{}{1}, wantErr: true},
+		{name: "invalid regex", expected: "matches /(/", actual: "x", wantErr: true},
+		{name: "non-numeric comparison", expected: ">10", actual: "abc", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			exp := parseExpectation(c.expected)
+			got, err := exp.evaluate(c.actual)
+
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("evaluate(%v) against %v = %v, want %v", c.expected, c.actual, got, c.want)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   interface{}
+		want    int
+		wantErr bool
+	}{
+		{name: "slice", value: []interface{}{1, 2, 3}, want: 3},
+		{name: "string", value: "hello", want: 5},
+		{name: "map", value: map[string]interface{}{"a": 1, "b": 2}, want: 2},
+		{name: "unsupported", value: 42, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := length(c.value)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("length(%v) = %d, want %d", c.value, got, c.want)
+			}
+		})
+	}
+}
+
+func TestAssertXPathsInvalidExpressionDoesNotPanic(t *testing.T) {
+	body := []byte(`<root><a>1</a></root>`)
+
+	err := assertXPaths(body, map[string]string{"//a[": "1"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid XPath expression, got none")
+	}
+}
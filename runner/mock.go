@@ -0,0 +1,193 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// RecordMode starts an httptest.Server that answers each incoming request by
+// matching its method, path, query string, and body against tests and
+// replying with the matched test's declared Response. This lets suites be
+// developed and regression-tested without a real backend, and enables
+// contract testing: point a client library at the server and verify it
+// issues requests that match the declared fixtures.
+func RecordMode(tests []builder.APITest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		test, ok := matchFixture(r, body, tests)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeFixtureResponse(w, test.Response)
+	}))
+}
+
+// matchFixture returns the first test whose Request matches r and body.
+func matchFixture(r *http.Request, body []byte, tests []builder.APITest) (builder.APITest, bool) {
+	for _, test := range tests {
+		if test.Method != "" && test.Method != r.Method {
+			continue
+		}
+		if test.Endpoint != r.URL.Path {
+			continue
+		}
+		if !queryMatches(test.Request.QueryParams, r.URL.Query()) {
+			continue
+		}
+		if !fixtureBodyMatches(test.Request, body) {
+			continue
+		}
+
+		return test, true
+	}
+
+	return builder.APITest{}, false
+}
+
+func queryMatches(expected map[string]string, actual url.Values) bool {
+	for key, value := range expected {
+		if actual.Get(key) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func fixtureBodyMatches(expected builder.Request, body []byte) bool {
+	if len(expected.JSON) > 0 {
+		var actual map[string]interface{}
+		if err := json.Unmarshal(body, &actual); err != nil {
+			return false
+		}
+		return assertJSON(actual, expected.JSON)
+	}
+
+	if expected.Body != "" {
+		return expected.Body == string(body)
+	}
+
+	return true
+}
+
+// writeFixtureResponse writes resp's status code, headers, and body to w.
+func writeFixtureResponse(w http.ResponseWriter, resp builder.APIResponse) {
+	for key, value := range resp.Headers {
+		w.Header().Set(key, value)
+	}
+
+	statusCode := resp.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
+
+	if len(resp.JSON) > 0 {
+		if contents, err := json.Marshal(resp.JSON); err == nil {
+			w.Write(contents)
+		}
+		return
+	}
+
+	if resp.Body != "" {
+		w.Write([]byte(resp.Body))
+	}
+}
+
+// Record starts an httptest.Server that acts as a reverse proxy against
+// target, capturing every request/response pair as a builder.APITest and
+// appending it to *tests. It lets users bootstrap a suite from real traffic
+// instead of writing fixtures by hand.
+func Record(target string, tests *[]builder.APITest) (*httptest.Server, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, r)
+
+		for key, values := range rec.Header() {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+
+		test := capturedTest(r, reqBody, rec)
+
+		mu.Lock()
+		*tests = append(*tests, test)
+		mu.Unlock()
+	}))
+
+	return server, nil
+}
+
+// capturedTest builds a builder.APITest fixture from a proxied request/response
+// pair, preferring JSON fields over raw bodies when the content parses as JSON.
+func capturedTest(r *http.Request, reqBody []byte, rec *httptest.ResponseRecorder) builder.APITest {
+	test := builder.APITest{
+		Method:   r.Method,
+		Endpoint: r.URL.Path,
+		Request: builder.Request{
+			Headers:     flattenHeader(r.Header),
+			QueryParams: flattenQuery(r.URL.Query()),
+			Body:        string(reqBody),
+		},
+		Response: builder.APIResponse{
+			StatusCode: rec.Code,
+			Body:       rec.Body.String(),
+			Headers:    flattenHeader(rec.Header()),
+		},
+	}
+
+	var reqJSON map[string]interface{}
+	if json.Unmarshal(reqBody, &reqJSON) == nil {
+		test.Request.JSON = reqJSON
+		test.Request.Body = ""
+	}
+
+	var respJSON map[string]interface{}
+	if json.Unmarshal(rec.Body.Bytes(), &respJSON) == nil {
+		test.Response.JSON = respJSON
+		test.Response.Body = ""
+	}
+
+	return test
+}
+
+func flattenHeader(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for key := range h {
+		out[key] = h.Get(key)
+	}
+	return out
+}
+
+func flattenQuery(q url.Values) map[string]string {
+	out := make(map[string]string, len(q))
+	for key := range q {
+		out[key] = q.Get(key)
+	}
+	return out
+}
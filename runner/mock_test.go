@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+func TestMatchFixture(t *testing.T) {
+	fixtures := []builder.APITest{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/users",
+			Request:  builder.Request{QueryParams: map[string]string{"active": "true"}},
+			Response: builder.APIResponse{StatusCode: http.StatusOK, Body: "active users"},
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/users",
+			Request:  builder.Request{JSON: map[string]interface{}{"name": "alice"}},
+			Response: builder.APIResponse{StatusCode: http.StatusCreated},
+		},
+	}
+
+	t.Run("matches on method, path, and query", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users?active=true", nil)
+
+		test, ok := matchFixture(req, nil, fixtures)
+		if !ok {
+			t.Fatal("expected a match, got none")
+		}
+		if test.Response.Body != "active users" {
+			t.Errorf("matched wrong fixture: %+v", test)
+		}
+	})
+
+	t.Run("matches on JSON body subset", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users", nil)
+
+		test, ok := matchFixture(req, []byte(`{"name":"alice","extra":1}`), fixtures)
+		if !ok {
+			t.Fatal("expected a match, got none")
+		}
+		if test.Response.StatusCode != http.StatusCreated {
+			t.Errorf("matched wrong fixture: %+v", test)
+		}
+	})
+
+	t.Run("no match for unknown path", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+
+		if _, ok := matchFixture(req, nil, fixtures); ok {
+			t.Error("expected no match for an unregistered path")
+		}
+	})
+
+	t.Run("no match when query param differs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users?active=false", nil)
+
+		if _, ok := matchFixture(req, nil, fixtures); ok {
+			t.Error("expected no match when a query param value differs")
+		}
+	})
+}
+
+func TestWriteFixtureResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	writeFixtureResponse(rec, builder.APIResponse{
+		StatusCode: http.StatusTeapot,
+		Headers:    map[string]string{"X-Test": "yes"},
+		JSON:       map[string]interface{}{"ok": true},
+	})
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if rec.Header().Get("X-Test") != "yes" {
+		t.Errorf("missing expected header, got %v", rec.Header())
+	}
+	if got := rec.Body.String(); got != `{"ok":true}` {
+		t.Errorf("body = %q, want %q", got, `{"ok":true}`)
+	}
+}
+
+func TestRecordModeServesMatchedFixture(t *testing.T) {
+	server := RecordMode([]builder.APITest{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/ping",
+			Response: builder.APIResponse{StatusCode: http.StatusOK, Body: "pong"},
+		},
+	})
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ping")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestRecordModeUnmatchedRequestIs404(t *testing.T) {
+	server := RecordMode(nil)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// AttemptRecord captures the outcome of a single attempt at running a test,
+// letting callers see per-attempt timings and errors for a flaky endpoint.
+type AttemptRecord struct {
+	Duration   time.Duration
+	StatusCode int
+	Error      error
+}
+
+// shouldRetryStatus reports whether status is listed in r.OnStatusCodes.
+func shouldRetryStatus(r builder.Retry, status int) bool {
+	for _, code := range r.OnStatusCodes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// maxBackoff caps the wait between retries so a large attempt count can't
+// double interval into overflowing time.Duration's int64 range.
+const maxBackoff = time.Hour
+
+// exponentialBackoff doubles interval once per attempt, capped at maxBackoff
+// to stay well clear of overflow regardless of how large attempt gets.
+func exponentialBackoff(interval time.Duration, attempt int) time.Duration {
+	wait := interval
+	for i := 0; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff || wait < 0 {
+		wait = maxBackoff
+	}
+	return wait
+}
+
+// backoff blocks for the duration prescribed by r.Backoff for the given
+// zero-indexed attempt number.
+func backoff(r builder.Retry, attempt int) {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+
+	switch r.Backoff {
+	case builder.BackoffExponential:
+		time.Sleep(exponentialBackoff(interval, attempt))
+	case builder.BackoffJitter:
+		max := exponentialBackoff(interval, attempt)
+		time.Sleep(time.Duration(rand.Int63n(int64(max) + 1)))
+	default: // builder.BackoffFixed
+		time.Sleep(interval)
+	}
+}
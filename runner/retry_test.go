@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+func TestShouldRetryStatus(t *testing.T) {
+	retry := builder.Retry{OnStatusCodes: []int{502, 503}}
+
+	if !shouldRetryStatus(retry, 502) {
+		t.Error("expected 502 to be retryable")
+	}
+	if shouldRetryStatus(retry, 200) {
+		t.Error("expected 200 to not be retryable")
+	}
+}
+
+func TestBackoffFixedWaitsAtLeastInterval(t *testing.T) {
+	start := time.Now()
+	backoff(builder.Retry{Backoff: builder.BackoffFixed, Interval: 10 * time.Millisecond}, 3)
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("expected to wait at least the fixed interval, waited %v", elapsed)
+	}
+}
+
+func TestExponentialBackoffDoubles(t *testing.T) {
+	wait := exponentialBackoff(10*time.Millisecond, 2)
+	want := 40 * time.Millisecond
+	if wait != want {
+		t.Errorf("exponentialBackoff(10ms, 2) = %v, want %v", wait, want)
+	}
+}
+
+// TestExponentialBackoffCapped guards against the overflow that a naive
+// interval*2^attempt computation hits for a large attempt count: the result
+// must be clamped to maxBackoff instead of wrapping into a negative duration.
+func TestExponentialBackoffCapped(t *testing.T) {
+	wait := exponentialBackoff(time.Second, 1000)
+	if wait != maxBackoff {
+		t.Errorf("expected exponential backoff to cap at %v, got %v", maxBackoff, wait)
+	}
+	if wait < 0 {
+		t.Fatalf("exponential backoff overflowed to a negative duration: %v", wait)
+	}
+}
+
+// TestExponentialBackoffJitterCapped checks the precondition backoff's jitter
+// branch relies on — rand.Int63n panics if handed n <= 0 — without invoking
+// backoff itself, since backoff sleeps for real and a capped max near
+// maxBackoff would block the test for up to an hour.
+func TestExponentialBackoffJitterCapped(t *testing.T) {
+	max := exponentialBackoff(time.Second, 1000)
+	if max+1 <= 0 {
+		t.Fatalf("rand.Int63n would be called with n <= 0 for max=%v", max)
+	}
+}
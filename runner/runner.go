@@ -2,11 +2,21 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
 
 	"github.com/JonathonGore/api-check/builder"
 )
@@ -16,6 +26,10 @@ type RunReport struct {
 	Successful     bool
 	Error          error
 	FailureMessage string
+
+	// Attempts records the duration, status code, and error of every attempt
+	// made at this test, including ones that were retried.
+	Attempts []AttemptRecord
 }
 
 // Consumes a map of string => string representing query params
@@ -81,12 +95,7 @@ func assertJSON(actual interface{}, expected interface{}) bool {
 
 // AssertResponse consume the http response from the server and the struct containing the
 // expected results and compares the two and ensures they are equal
-func assertResponse(resp *http.Response, expected builder.APIResponse) (bool, error) {
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return false, err
-	}
-
+func assertResponse(resp *http.Response, body []byte, expected builder.APIResponse) (bool, error) {
 	// Ensure status code is what is expected
 	if expected.StatusCode != resp.StatusCode {
 		return false, fmt.Errorf("Unexpected status code received\n\nExpected:\n%v\n\nActual:\n%v\n\n", expected.StatusCode, resp.StatusCode)
@@ -102,7 +111,7 @@ func assertResponse(resp *http.Response, expected builder.APIResponse) (bool, er
 	if string(body) != "" {
 		actual := make(map[string]interface{})
 
-		err = json.Unmarshal(body, &actual)
+		err := json.Unmarshal(body, &actual)
 
 		// Case where we cannot unmarshal response body as JSON but user has some JSON to check for
 		if err != nil && (expected.JSON != nil || len(expected.JSON) > 0) {
@@ -114,6 +123,34 @@ func assertResponse(resp *http.Response, expected builder.APIResponse) (bool, er
 		}
 	}
 
+	// Evaluate any JSONPath/XPath expression matchers, which let callers assert
+	// on deep-nested fields without comparing the whole payload.
+	if err := assertJSONPaths(body, expected.JSONPath); err != nil {
+		return false, err
+	}
+	if err := assertXPaths(body, expected.XPath); err != nil {
+		return false, err
+	}
+
+	// Evaluate a pluggable BodyMatcher, keyed off the response Content-Type, for
+	// content types JSON/JSONPath/XPath can't express (XML, YAML, protobuf, multipart).
+	if expected.Match != nil {
+		contentType := resp.Header.Get("Content-Type")
+
+		matcher, ok := lookupBodyMatcher(contentType)
+		if !ok {
+			return false, fmt.Errorf("no BodyMatcher registered for content type %q", contentType)
+		}
+
+		matched, err := matcher.Match(contentType, body, expected.Match)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, fmt.Errorf("Mismatching %v body\n\nExpected:\n%v\n\nActual:\n%v\n\n", contentType, expected.Match, string(body))
+		}
+	}
+
 	// Ensure headers are what we expect
 	for key, value := range expected.Headers {
 		if value != resp.Header.Get(key) {
@@ -124,25 +161,72 @@ func assertResponse(resp *http.Response, expected builder.APIResponse) (bool, er
 	return true, nil
 }
 
+// interpolate replaces every "${name}" placeholder in s with vars[name]. Names
+// with no entry in vars are left untouched.
+func interpolate(s string, vars map[string]string) string {
+	for name, value := range vars {
+		s = strings.ReplaceAll(s, "${"+name+"}", value)
+	}
+
+	return s
+}
+
+// interpolateJSON walks v, a value from a decoded JSON document, applying
+// interpolate to every string it finds.
+func interpolateJSON(v interface{}, vars map[string]string) interface{} {
+	switch t := v.(type) {
+	case string:
+		return interpolate(t, vars)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for key, val := range t {
+			out[key] = interpolateJSON(val, vars)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = interpolateJSON(val, vars)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 // BuildRequest consumes an api test object and produces the corresponding http request
-// that will be sent by the http client
-func buildRequest(test builder.APITest) (*http.Request, error) {
-	u, err := buildURL(test.Hostname, test.Endpoint, test.Request.QueryParams)
+// that will be sent by the http client. vars is applied as ${name} interpolation over
+// the test's hostname, endpoint, headers, query params, and body before the request is built.
+func buildRequest(test builder.APITest, vars map[string]string) (*http.Request, error) {
+	hostname := interpolate(test.Hostname, vars)
+	endpoint := interpolate(test.Endpoint, vars)
+
+	query := make(map[string]string, len(test.Request.QueryParams))
+	for key, value := range test.Request.QueryParams {
+		query[key] = interpolate(value, vars)
+	}
+
+	u, err := buildURL(hostname, endpoint, query)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(test.Request.Files) > 0 {
+		return buildMultipartRequest(test, u, vars)
+	}
+
 	var buffer *bytes.Buffer
 
 	// Only attach json to body if its non-nil with at least 1 key
 	if test.Request.JSON != nil || len(test.Request.JSON) > 0 {
-		contents, err := json.Marshal(test.Request.JSON)
+		interpolated := interpolateJSON(test.Request.JSON, vars)
+		contents, err := json.Marshal(interpolated)
 		if err != nil {
 			return nil, err
 		}
 		buffer = bytes.NewBuffer(contents)
 	} else {
-		buffer = bytes.NewBuffer([]byte(test.Request.Body))
+		buffer = bytes.NewBuffer([]byte(interpolate(test.Request.Body, vars)))
 	}
 
 	// Build request object attaching the specified method, url and body
@@ -153,46 +237,264 @@ func buildRequest(test builder.APITest) (*http.Request, error) {
 
 	// Attach the specified request headers
 	for key, value := range test.Request.Headers {
-		req.Header.Set(key, value)
+		req.Header.Set(key, interpolate(value, vars))
 	}
 
 	return req, nil
 }
 
-// RunTest consumes a test object and runs the test against the configured server
-// produces a RunReport of the results
-func RunTest(test builder.APITest) RunReport {
+// buildMultipartRequest builds a multipart/form-data request with one file
+// part per entry in test.Request.Files (form field => filepath), plus one
+// text part per entry in test.Request.JSON.
+func buildMultipartRequest(test builder.APITest, u string, vars map[string]string) (*http.Request, error) {
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+
+	for field, path := range test.Request.Files {
+		file, err := os.Open(interpolate(path, vars))
+		if err != nil {
+			return nil, err
+		}
+
+		part, err := writer.CreateFormFile(field, filepath.Base(path))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		if _, err := io.Copy(part, file); err != nil {
+			file.Close()
+			return nil, err
+		}
+		file.Close()
+	}
+
+	for field, value := range test.Request.JSON {
+		if err := writer.WriteField(field, interpolate(fmt.Sprintf("%v", value), vars)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(test.Method, u, buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range test.Request.Headers {
+		req.Header.Set(key, interpolate(value, vars))
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req, nil
+}
+
+// runWithVars builds and executes test's request with ${var} interpolation from
+// vars, asserts the response, and also returns the raw body and headers so
+// callers (namely RunSuite) can extract values for later tests in a chain. It
+// retries according to test.Retry, bounds each attempt with test.Timeout, and
+// fails the test if the response arrives later than test.MaxLatency.
+func runWithVars(test builder.APITest, client *http.Client, vars map[string]string) (RunReport, []byte, http.Header) {
 	report := RunReport{
 		Successful: false,
 		Test:       test,
 	}
 
-	client := &http.Client{} // TODO: Will eventually load a bunch of config from conf file
-
-	req, err := buildRequest(test)
-	if err != nil {
-		report.Error = err
-		return report
+	maxAttempts := test.Retry.Count + 1
+	if maxAttempts < 1 {
+		maxAttempts = 1
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		report.Error = err
-		return report
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		req, err := buildRequest(test, vars)
+		if err != nil {
+			report.Error = err
+			return report, nil, nil
+		}
+
+		var cancel context.CancelFunc
+		if test.Timeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(context.Background(), test.Timeout)
+			req = req.WithContext(ctx)
+		}
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		duration := time.Since(start)
+
+		if err != nil {
+			if cancel != nil {
+				cancel()
+			}
+			report.Error = err
+			report.Attempts = append(report.Attempts, AttemptRecord{Duration: duration, Error: err})
+
+			if attempt < maxAttempts-1 && test.Retry.OnNetworkError {
+				backoff(test.Retry, attempt)
+				continue
+			}
+
+			return report, nil, nil
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		// The body is read under the attempt's context, so it is only released now.
+		if cancel != nil {
+			cancel()
+		}
+		if err != nil {
+			report.Error = err
+			report.Attempts = append(report.Attempts, AttemptRecord{Duration: duration, StatusCode: resp.StatusCode, Error: err})
+			return report, nil, nil
+		}
+
+		report.Attempts = append(report.Attempts, AttemptRecord{Duration: duration, StatusCode: resp.StatusCode})
+
+		if attempt < maxAttempts-1 && shouldRetryStatus(test.Retry, resp.StatusCode) {
+			backoff(test.Retry, attempt)
+			continue
+		}
+
+		// Compare result to expected result
+		report.Successful, report.Error = assertResponse(resp, body, test.Response)
+
+		if test.MaxLatency > 0 && duration > test.MaxLatency {
+			report.Successful = false
+			report.FailureMessage = fmt.Sprintf("response took %v, exceeding MaxLatency of %v", duration, test.MaxLatency)
+		}
+
+		return report, body, resp.Header
 	}
 
-	// Compare result to expected result
-	report.Successful, report.Error = assertResponse(resp, test.Response)
+	return report, nil, nil
+}
 
+// RunTest consumes a test object and runs the test against the configured server
+// produces a RunReport of the results. The supplied client is used to perform the
+// request, allowing callers to share connection pools and configure timeouts, TLS,
+// and proxies from a single place (e.g. a conf file) instead of per-call.
+func RunTest(test builder.APITest, client *http.Client) RunReport {
+	report, _, _ := runWithVars(test, client, test.Variables)
 	return report
 }
 
 func RunTests(tests []builder.APITest) []RunReport {
+	client := &http.Client{}
 	reports := make([]RunReport, len(tests))
 
 	for i, test := range tests {
-		reports[i] = RunTest(test)
+		reports[i] = RunTest(test, client)
+	}
+
+	return reports
+}
+
+// RunOptions configures how RunTestsConcurrent executes a suite of tests.
+type RunOptions struct {
+	// Parallelism is the number of tests that may be in flight at once. Values <= 0
+	// are treated as 1.
+	Parallelism int
+
+	// RateLimitPerSecond caps the total number of requests issued per second across
+	// every worker. Values <= 0 disable the global rate limit.
+	RateLimitPerSecond int
+
+	// PerHostConcurrency caps the number of in-flight requests to any single host,
+	// determined by test.Hostname. Values <= 0 disable the per-host limit.
+	PerHostConcurrency int
+
+	// Client is shared by every request so callers can reuse connection pools and
+	// configure timeouts, TLS, and proxies in one place. If nil, a default
+	// http.Client is used.
+	Client *http.Client
+}
+
+// hostThrottle hands out per-host semaphores on demand, creating one the first
+// time a given hostname is seen.
+type hostThrottle struct {
+	limit int
+	mu    sync.Mutex
+	sems  map[string]chan struct{}
+}
+
+func newHostThrottle(limit int) *hostThrottle {
+	return &hostThrottle{limit: limit, sems: make(map[string]chan struct{})}
+}
+
+// acquire blocks until a slot for hostname is available and returns the release
+// function. If the throttle has no limit configured it returns a no-op.
+func (h *hostThrottle) acquire(hostname string) func() {
+	if h.limit <= 0 {
+		return func() {}
+	}
+
+	h.mu.Lock()
+	sem, ok := h.sems[hostname]
+	if !ok {
+		sem = make(chan struct{}, h.limit)
+		h.sems[hostname] = sem
+	}
+	h.mu.Unlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+// RunTestsConcurrent runs tests across a bounded worker pool, optionally capping
+// the global request rate and the number of in-flight requests per host. Reports
+// are returned in the same order as the input tests regardless of completion order.
+func RunTestsConcurrent(tests []builder.APITest, opts RunOptions) []RunReport {
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = 1
 	}
 
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	var limiter *rate.Limiter
+	if opts.RateLimitPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.RateLimitPerSecond), opts.RateLimitPerSecond)
+	}
+
+	throttle := newHostThrottle(opts.PerHostConcurrency)
+
+	reports := make([]RunReport, len(tests))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				test := tests[i]
+
+				if limiter != nil {
+					limiter.Wait(context.Background())
+				}
+
+				release := throttle.acquire(test.Hostname)
+				reports[i] = RunTest(test, client)
+				release()
+			}
+		}()
+	}
+
+	for i := range tests {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
 	return reports
 }